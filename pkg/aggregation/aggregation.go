@@ -0,0 +1,293 @@
+// Package aggregation computes a single composite quotation from
+// per-exchange trade data using a configurable filter (MEDIAN, VWAP or
+// TWAP).
+package aggregation
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoTrades is returned by Aggregate when no trades were found for the
+// symbol (and, if given, exchanges) within the requested window.
+var ErrNoTrades = errors.New("no trades found for symbol in window")
+
+// Filter selects how per-exchange prices are combined into one composite
+// price.
+type Filter string
+
+const (
+	Median Filter = "median"
+	VWAP   Filter = "vwap"
+	TWAP   Filter = "twap"
+)
+
+// ParseFilter validates a user-supplied filter name, case-insensitively.
+func ParseFilter(raw string) (Filter, error) {
+	switch Filter(strings.ToLower(raw)) {
+	case Median:
+		return Median, nil
+	case VWAP:
+		return VWAP, nil
+	case TWAP:
+		return TWAP, nil
+	default:
+		return "", errors.New("filter must be one of median, vwap, twap")
+	}
+}
+
+// Trade is one executed trade on a given exchange, the raw input to
+// aggregation.
+type Trade struct {
+	Exchange string
+	Price    float64
+	Volume   float64
+	Time     time.Time
+}
+
+// TradeSource supplies the trades an Aggregator combines, typically backed
+// by the same datastore the rest of the API reads from.
+type TradeSource interface {
+	// GetTrades returns trades for symbol within [from, to]. An empty
+	// exchange means "across every exchange known for symbol".
+	GetTrades(exchange, symbol string, from, to time.Time) ([]Trade, error)
+}
+
+// ExchangeContribution is one exchange's input into an AggregatedQuotation,
+// included in the response so callers can audit how the composite price was
+// derived.
+type ExchangeContribution struct {
+	Exchange string  `json:"exchange"`
+	Price    float64 `json:"price"`
+	Volume   float64 `json:"volume"`
+	Samples  int     `json:"samples"`
+}
+
+// AggregatedQuotation is the result of combining per-exchange trades into a
+// single composite price.
+type AggregatedQuotation struct {
+	Symbol        string                 `json:"symbol"`
+	Filter        Filter                 `json:"filter"`
+	Price         float64                `json:"price"`
+	Volume        float64                `json:"volume"`
+	Samples       int                    `json:"samples"`
+	WindowStart   time.Time              `json:"windowStart"`
+	WindowEnd     time.Time              `json:"windowEnd"`
+	Contributions []ExchangeContribution `json:"contributions"`
+}
+
+// Aggregator computes an AggregatedQuotation for a symbol from per-exchange
+// trade data.
+type Aggregator interface {
+	Aggregate(symbol string, filter Filter, window time.Duration, exchanges []string) (*AggregatedQuotation, error)
+}
+
+// tradeAggregator is the default Aggregator, backed by a TradeSource.
+type tradeAggregator struct {
+	trades TradeSource
+}
+
+// NewAggregator returns an Aggregator reading trades from source.
+func NewAggregator(source TradeSource) Aggregator {
+	return &tradeAggregator{trades: source}
+}
+
+// Aggregate implements Aggregator. MEDIAN sorts the exchanges' last-trade
+// prices (one per exchange) and takes the middle; VWAP divides Σ(price·volume) by Σ(volume),
+// per exchange and then across exchanges; TWAP integrates the step function
+// of trade prices over the window and divides by its length.
+func (a *tradeAggregator) Aggregate(symbol string, filter Filter, window time.Duration, exchanges []string) (*AggregatedQuotation, error) {
+	end := time.Now()
+	start := end.Add(-window)
+
+	grouped, err := a.tradesByExchange(symbol, exchanges, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(grouped) == 0 {
+		return nil, ErrNoTrades
+	}
+
+	contributions := make([]ExchangeContribution, 0, len(grouped))
+	var totalVolume float64
+	var totalSamples int
+	for exchange, trades := range grouped {
+		price := exchangePrice(filter, trades, start, end)
+		volume := totalVolumeOf(trades)
+		contributions = append(contributions, ExchangeContribution{
+			Exchange: exchange,
+			Price:    price,
+			Volume:   volume,
+			Samples:  len(trades),
+		})
+		totalVolume += volume
+		totalSamples += len(trades)
+	}
+	sort.Slice(contributions, func(i, j int) bool { return contributions[i].Exchange < contributions[j].Exchange })
+
+	return &AggregatedQuotation{
+		Symbol:        symbol,
+		Filter:        filter,
+		Price:         compositePrice(filter, contributions),
+		Volume:        totalVolume,
+		Samples:       totalSamples,
+		WindowStart:   start,
+		WindowEnd:     end,
+		Contributions: contributions,
+	}, nil
+}
+
+// tradesByExchange fetches trades for symbol and groups them by exchange,
+// restricting to exchanges when it is non-empty.
+func (a *tradeAggregator) tradesByExchange(symbol string, exchanges []string, start, end time.Time) (map[string][]Trade, error) {
+	trades, err := a.trades.GetTrades("", symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool, len(exchanges))
+	for _, exchange := range exchanges {
+		allowed[exchange] = true
+	}
+
+	grouped := make(map[string][]Trade)
+	for _, t := range trades {
+		if len(allowed) > 0 && !allowed[t.Exchange] {
+			continue
+		}
+		grouped[t.Exchange] = append(grouped[t.Exchange], t)
+	}
+	return grouped, nil
+}
+
+func totalVolumeOf(trades []Trade) float64 {
+	var volume float64
+	for _, t := range trades {
+		volume += t.Volume
+	}
+	return volume
+}
+
+func exchangePrice(filter Filter, trades []Trade, start, end time.Time) float64 {
+	switch filter {
+	case Median:
+		return lastTradePrice(trades)
+	case TWAP:
+		return twap(trades, start, end)
+	default: // VWAP
+		return vwap(trades)
+	}
+}
+
+// lastTradePrice returns the price of the most recent trade.
+func lastTradePrice(trades []Trade) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+	last := trades[0]
+	for _, t := range trades[1:] {
+		if t.Time.After(last.Time) {
+			last = t
+		}
+	}
+	return last.Price
+}
+
+func medianPrice(trades []Trade) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+	prices := make([]float64, len(trades))
+	for i, t := range trades {
+		prices[i] = t.Price
+	}
+	sort.Float64s(prices)
+
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return (prices[mid-1] + prices[mid]) / 2
+	}
+	return prices[mid]
+}
+
+func vwap(trades []Trade) float64 {
+	var priceVolume, volume float64
+	for _, t := range trades {
+		priceVolume += t.Price * t.Volume
+		volume += t.Volume
+	}
+	if volume == 0 {
+		return medianPrice(trades)
+	}
+	return priceVolume / volume
+}
+
+// twap integrates the step function of trade prices over [start, end] so
+// that the price between two trades is weighted by how long it held, then
+// divides by the window length.
+func twap(trades []Trade, start, end time.Time) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+
+	sorted := make([]Trade, len(trades))
+	copy(sorted, trades)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var integral float64
+	prevTime := start
+	prevPrice := sorted[0].Price
+	for _, t := range sorted {
+		if t.Time.Before(start) {
+			prevPrice = t.Price
+			continue
+		}
+		if t.Time.After(end) {
+			break
+		}
+		integral += prevPrice * t.Time.Sub(prevTime).Seconds()
+		prevTime = t.Time
+		prevPrice = t.Price
+	}
+	integral += prevPrice * end.Sub(prevTime).Seconds()
+
+	duration := end.Sub(start).Seconds()
+	if duration <= 0 {
+		return prevPrice
+	}
+	return integral / duration
+}
+
+// compositePrice combines each exchange's contribution into one price: the
+// median of the exchanges' last-trade prices for MEDIAN, and a
+// volume-weighted average for VWAP/TWAP.
+func compositePrice(filter Filter, contributions []ExchangeContribution) float64 {
+	if filter == Median {
+		prices := make([]float64, len(contributions))
+		for i, c := range contributions {
+			prices[i] = c.Price
+		}
+		sort.Float64s(prices)
+		mid := len(prices) / 2
+		if len(prices)%2 == 0 {
+			return (prices[mid-1] + prices[mid]) / 2
+		}
+		return prices[mid]
+	}
+
+	var priceVolume, volume float64
+	for _, c := range contributions {
+		priceVolume += c.Price * c.Volume
+		volume += c.Volume
+	}
+	if volume == 0 {
+		var sum float64
+		for _, c := range contributions {
+			sum += c.Price
+		}
+		return sum / float64(len(contributions))
+	}
+	return priceVolume / volume
+}
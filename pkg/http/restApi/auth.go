@@ -0,0 +1,167 @@
+package restApi
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthSourceKey is the gin.Context key AuthMiddleware stores the
+// authenticated source under once a request passes verification.
+const AuthSourceKey = "diaAuthSource"
+
+// maxClockSkew bounds how far a request's X-DIA-Timestamp may drift from the
+// server clock before it is rejected.
+const maxClockSkew = 60 * time.Second
+
+// SourceCredential is the authentication material registered for a data
+// source. Exactly one of HMACSecret or Ed25519PubKey is expected to be set,
+// depending on which scheme the source signs with.
+type SourceCredential struct {
+	Source        string
+	HMACSecret    string
+	Ed25519PubKey []byte
+}
+
+// SourceKeyStore resolves and rotates the credentials AuthMiddleware checks
+// signatures against.
+type SourceKeyStore interface {
+	Get(source string) (*SourceCredential, error)
+	Rotate(source string, cred *SourceCredential) error
+}
+
+// NonceStore rejects replayed requests. Implementations are expected to
+// apply a TTL themselves (e.g. a Redis SETNX with expiry) so the store does
+// not grow unbounded.
+type NonceStore interface {
+	// SeenBefore records nonce for source within window and reports whether
+	// it had already been used.
+	SeenBefore(source, nonce string, window time.Duration) (bool, error)
+}
+
+// AuthMiddleware authenticates write requests using either an HMAC-SHA256
+// signature over "timestamp|method|path|body" with a per-source shared
+// secret, or an Ed25519 signature with the source's registered public key.
+// It rejects requests whose timestamp is more than 60s skewed or whose nonce
+// has already been seen. On success the authenticated source is stored under
+// AuthSourceKey for handlers to compare against request payload fields.
+//
+// Expected headers: X-DIA-Key (source id), X-DIA-Timestamp (unix seconds),
+// X-DIA-Nonce, X-DIA-Signature (hex for HMAC, base64 for Ed25519).
+func AuthMiddleware(keys SourceKeyStore, nonces NonceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		source := c.GetHeader("X-DIA-Key")
+		if source == "" {
+			SendError(c, http.StatusUnauthorized, errors.New("missing X-DIA-Key header"))
+			c.Abort()
+			return
+		}
+
+		cred, err := keys.Get(source)
+		if err != nil {
+			SendError(c, http.StatusUnauthorized, errors.New("unknown source"))
+			c.Abort()
+			return
+		}
+
+		timestamp := c.GetHeader("X-DIA-Timestamp")
+		nonce := c.GetHeader("X-DIA-Nonce")
+		signature := c.GetHeader("X-DIA-Signature")
+		if timestamp == "" || nonce == "" || signature == "" {
+			SendError(c, http.StatusUnauthorized, errors.New("missing timestamp, nonce or signature header"))
+			c.Abort()
+			return
+		}
+
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			SendError(c, http.StatusUnauthorized, errors.New("invalid timestamp"))
+			c.Abort()
+			return
+		}
+		skew := time.Since(time.Unix(ts, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > maxClockSkew {
+			SendError(c, http.StatusUnauthorized, errors.New("timestamp outside allowed skew"))
+			c.Abort()
+			return
+		}
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			SendError(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		message := strings.Join([]string{timestamp, c.Request.Method, c.Request.URL.Path, string(body)}, "|")
+
+		var verified bool
+		switch {
+		case cred.HMACSecret != "":
+			verified = verifyHMAC(cred.HMACSecret, message, signature)
+		case len(cred.Ed25519PubKey) > 0:
+			verified = verifyEd25519(cred.Ed25519PubKey, message, signature)
+		}
+		if !verified {
+			SendError(c, http.StatusUnauthorized, errors.New("invalid signature"))
+			c.Abort()
+			return
+		}
+
+		replayed, err := nonces.SeenBefore(source, nonce, 2*maxClockSkew)
+		if err != nil {
+			SendError(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+		if replayed {
+			SendError(c, http.StatusUnauthorized, errors.New("replayed nonce"))
+			c.Abort()
+			return
+		}
+
+		c.Set(AuthSourceKey, source)
+		c.Next()
+	}
+}
+
+func verifyHMAC(secret, message, signatureHex string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	expected := mac.Sum(nil)
+
+	given, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, given)
+}
+
+func verifyEd25519(pubKey []byte, message, signatureB64 string) bool {
+	// ed25519.Verify panics if the key isn't exactly ed25519.PublicKeySize
+	// bytes, so a malformed stored key must never reach it.
+	if len(pubKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signatureB64)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pubKey), []byte(message), sig)
+}
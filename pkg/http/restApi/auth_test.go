@@ -0,0 +1,61 @@
+package restApi
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyHMAC(t *testing.T) {
+	secret := "s3cret"
+	message := "1690000000|POST|/v1/supply|{}"
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	if !verifyHMAC(secret, message, signature) {
+		t.Fatal("expected a correctly signed message to verify")
+	}
+	if verifyHMAC(secret, message+"tampered", signature) {
+		t.Fatal("expected a tampered message to fail verification")
+	}
+	if verifyHMAC("wrong-secret", message, signature) {
+		t.Fatal("expected verification to fail with the wrong secret")
+	}
+	if verifyHMAC(secret, message, "not-hex") {
+		t.Fatal("expected a malformed signature to fail verification")
+	}
+}
+
+func TestVerifyEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := "1690000000|POST|/v1/supply|{}"
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(message)))
+
+	if !verifyEd25519(pub, message, signature) {
+		t.Fatal("expected a correctly signed message to verify")
+	}
+	if verifyEd25519(pub, message+"tampered", signature) {
+		t.Fatal("expected a tampered message to fail verification")
+	}
+	if verifyEd25519(pub, message, "not-base64") {
+		t.Fatal("expected a malformed signature to fail verification")
+	}
+}
+
+func TestVerifyEd25519RejectsMalformedKeyLength(t *testing.T) {
+	// ed25519.Verify panics on a key that isn't exactly
+	// ed25519.PublicKeySize bytes; verifyEd25519 must guard against that
+	// instead of letting the panic reach the request-handling goroutine.
+	shortKey := make([]byte, ed25519.PublicKeySize-1)
+	if verifyEd25519(shortKey, "message", "") {
+		t.Fatal("expected a malformed key to fail verification, not verify")
+	}
+}
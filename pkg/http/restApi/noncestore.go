@@ -0,0 +1,26 @@
+package restApi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// RedisNonceStore is a NonceStore backed by Redis SETNX, so a replayed nonce
+// is rejected even when requests from the same source land on different API
+// replicas.
+type RedisNonceStore struct {
+	Client *redis.Client
+}
+
+// SeenBefore records source/nonce with the given TTL and reports whether it
+// was already present.
+func (s *RedisNonceStore) SeenBefore(source, nonce string, window time.Duration) (bool, error) {
+	key := fmt.Sprintf("nonce:%s:%s", source, nonce)
+	stored, err := s.Client.SetNX(key, "1", window).Result()
+	if err != nil {
+		return false, err
+	}
+	return !stored, nil
+}
@@ -0,0 +1,13 @@
+package diaApi
+
+import (
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+	"github.com/diadata-org/diadata/pkg/model/sourcekey"
+)
+
+// NewSourceKeyStore returns a restApi.SourceKeyStore backed by a SQL table,
+// picking the dialect from dsn the same way securitytoken.NewRepository
+// does.
+func NewSourceKeyStore(dsn string) (restApi.SourceKeyStore, error) {
+	return sourcekey.NewStore(dsn)
+}
@@ -1,38 +1,83 @@
 package diaApi
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/diadata-org/diadata/pkg/aggregation"
 	"github.com/diadata-org/diadata/pkg/dia"
 	"github.com/diadata-org/diadata/pkg/dia/helpers"
 	"github.com/diadata-org/diadata/pkg/http/restApi"
 	"github.com/diadata-org/diadata/pkg/model"
+	"github.com/diadata-org/diadata/pkg/model/securitytoken"
+	"github.com/diadata-org/diadata/pkg/oracle"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
-	"database/sql"
 )
 
 type Env struct {
 	DataStore models.Datastore
+	// PriceProvider is an optional fallback quotation source, consulted when
+	// DataStore has no data for a requested symbol.
+	PriceProvider PriceProvider
+	// SecurityTokens is the repository backing the security-token endpoints.
+	SecurityTokens securitytoken.Repository
+
+	// Aggregator computes the composite MEDIAN/VWAP/TWAP quotation served by
+	// GetAggregatedQuotation and PostPublishOracle.
+	Aggregator aggregation.Aggregator
+
+	// AuthKeys resolves and rotates the per-source signing credentials
+	// checked by restApi.AuthMiddleware.
+	AuthKeys restApi.SourceKeyStore
+
+	// Oracle, if configured, lets GetAggregatedQuotation results be pushed
+	// on-chain via PostPublishOracle.
+	Oracle *oracle.Publisher
+
+	stream *streamHub
+}
+
+var errNoStreamHub = errors.New("websocket streaming is not configured, call Env.InitStream first")
+
+// InitStream wires up the /v1/ws websocket hub, piggybacking on the given
+// Redis client for cross-replica pub/sub fan-out. It must be called once
+// before GetWS is registered as a route.
+func (env *Env) InitStream(client *redis.Client) {
+	env.stream = newStreamHub(client)
+	go env.stream.run()
 }
 
 // PostSupply godoc
 // @Summary Post the circulating supply
-// @Description Post the circulating supply
+// @Description Post the circulating supply. Requires a request signed per restApi.AuthMiddleware.
 // @Tags dia
 // @Accept  json
 // @Produce  json
 // @Param Symbol query string true "Coin symbol"
 // @Param CirculatingSupply query float64 true "number of coins in circulating supply"
 // @Success 200 {object} dia.Supply	"success"
+// @Failure 401 {object} restApi.APIError "unauthorized"
+// @Failure 403 {object} restApi.APIError "source mismatch"
 // @Failure 500 {object} restApi.APIError "error"
 // @Router /v1/supply [post]
 func (env *Env) PostSupply(c *gin.Context) {
 
+	authSource, ok := c.Get(restApi.AuthSourceKey)
+	if !ok {
+		restApi.SendError(c, http.StatusUnauthorized, errors.New("missing authenticated source"))
+		return
+	}
+
 	body, err := ioutil.ReadAll(c.Request.Body)
 	if err != nil {
 		restApi.SendError(c, http.StatusInternalServerError, errors.New("ReadAll"))
@@ -45,11 +90,15 @@ func (env *Env) PostSupply(c *gin.Context) {
 			if t.Symbol == "" || t.CirculatingSupply == 0.0 {
 				log.Errorln("received supply:", t)
 				restApi.SendError(c, http.StatusInternalServerError, errors.New("Missing Symbol or CirculatingSupply value"))
+			} else if t.Source != "" && t.Source != authSource {
+				restApi.SendError(c, http.StatusForbidden, errors.New("Source does not match authenticated identity"))
 			} else {
 				log.Println("received supply:", t)
 				source := dia.Diadata
 				if t.Source != "" {
 					source = t.Source
+				} else {
+					source = authSource.(string)
 				}
 				s := &dia.Supply{
 					Time:              time.Now(),
@@ -85,6 +134,15 @@ func (env *Env) GetQuotation(c *gin.Context) {
 	symbol := c.Param("symbol")
 	q, err := env.DataStore.GetQuotation(symbol)
 	if err != nil {
+		if err == redis.Nil && env.PriceProvider != nil {
+			fallback, ferr := env.getQuotationFromProvider(symbol)
+			if ferr != nil {
+				sendProviderError(c, ferr)
+				return
+			}
+			c.JSON(http.StatusOK, fallback)
+			return
+		}
 		if err == redis.Nil {
 			restApi.SendError(c, http.StatusNotFound, err)
 		} else {
@@ -95,6 +153,175 @@ func (env *Env) GetQuotation(c *gin.Context) {
 	}
 }
 
+// getQuotationFromProvider asks env.PriceProvider for the most recent daily
+// market chart point and persists it into DataStore so subsequent requests
+// for the same symbol are served locally.
+func (env *Env) getQuotationFromProvider(symbol string) (*models.Quotation, error) {
+	points, err := env.PriceProvider.MarketChart(symbol, 1, "daily")
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, ErrCoinNotFound
+	}
+
+	last := points[len(points)-1]
+	q := &models.Quotation{
+		Symbol: symbol,
+		Name:   helpers.NameForSymbol(symbol),
+		Price:  last.Price,
+		Time:   last.Time,
+		Source: "CoinGecko",
+	}
+	if err := env.DataStore.SetQuotation(q); err != nil {
+		log.Errorln("persist fallback quotation:", err)
+	}
+	env.publishQuotation(q)
+	return q, nil
+}
+
+// publishQuotation fans q out to any /v1/ws clients subscribed to
+// "quotation:<symbol>". It is a no-op when streaming isn't configured, and
+// this fallback-cache write is only one possible producer: anything else in
+// the stack that writes a quotation or trade can publish to the same
+// "quotation:<symbol>"/"trades:<symbol>" channels to reach the same clients.
+func (env *Env) publishQuotation(q *models.Quotation) {
+	if env.stream == nil {
+		return
+	}
+	if err := env.stream.Publish("quotation:"+strings.ToUpper(q.Symbol), q); err != nil {
+		log.Errorln("ws: publish quotation", err)
+	}
+}
+
+// sendProviderError maps a PriceProvider error to the matching HTTP status.
+func sendProviderError(c *gin.Context, err error) {
+	switch err {
+	case ErrCoinNotFound:
+		restApi.SendError(c, http.StatusNotFound, err)
+	case ErrTooManyRequests:
+		c.Header("Retry-After", "60")
+		restApi.SendError(c, http.StatusTooManyRequests, err)
+	default:
+		restApi.SendError(c, http.StatusInternalServerError, err)
+	}
+}
+
+// GetQuotationHistory godoc
+// @Summary Get historical quotation
+// @Description Get historical daily price points for a symbol from the configured PriceProvider
+// @Tags dia
+// @Accept  json
+// @Produce  json
+// @Param   symbol     path    string     true   "Some symbol"
+// @Param   days       query   int        false  "number of days of history, default 30"
+// @Param   interval   query   string     false  "sampling interval, default daily"
+// @Success 200 {array} diaApi.MarketChartPoint "success"
+// @Failure 404 {object} restApi.APIError "Symbol not found"
+// @Failure 429 {object} restApi.APIError "Too many requests"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/quotation/:symbol/history [get]
+func (env *Env) GetQuotationHistory(c *gin.Context) {
+	if env.PriceProvider == nil {
+		restApi.SendError(c, http.StatusInternalServerError, errors.New("no price provider configured"))
+		return
+	}
+
+	symbol := c.Param("symbol")
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	interval := c.DefaultQuery("interval", "daily")
+
+	points, err := env.PriceProvider.MarketChart(symbol, days, interval)
+	if err != nil {
+		sendProviderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, points)
+}
+
+// GetMarketChart godoc
+// @Summary Get market chart
+// @Description Get historical market chart data for a symbol, mirroring CoinGecko's market_chart naming
+// @Tags dia
+// @Accept  json
+// @Produce  json
+// @Param   symbol     path    string     true   "Some symbol"
+// @Param   days       query   int        false  "number of days of history, default 30"
+// @Param   interval   query   string     false  "sampling interval, default daily"
+// @Success 200 {array} diaApi.MarketChartPoint "success"
+// @Failure 404 {object} restApi.APIError "Symbol not found"
+// @Failure 429 {object} restApi.APIError "Too many requests"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/marketchart/:symbol [get]
+func (env *Env) GetMarketChart(c *gin.Context) {
+	env.GetQuotationHistory(c)
+}
+
+// parseAggregationParams extracts the filter/window/exchanges query
+// parameters shared by GetAggregatedQuotation and PostPublishOracle.
+func parseAggregationParams(c *gin.Context) (aggregation.Filter, time.Duration, []string, error) {
+	filter, err := aggregation.ParseFilter(c.DefaultQuery("filter", "vwap"))
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil {
+		return "", 0, nil, errors.New("invalid window")
+	}
+
+	var exchanges []string
+	if raw := c.Query("exchanges"); raw != "" {
+		exchanges = strings.Split(raw, ",")
+	}
+
+	return filter, window, exchanges, nil
+}
+
+// GetAggregatedQuotation godoc
+// @Summary Get aggregated multi-exchange quotation
+// @Description Combine per-exchange trade prices over window into one composite price using MEDIAN, VWAP or TWAP
+// @Tags dia
+// @Accept  json
+// @Produce  json
+// @Param   symbol     path    string     true   "Some symbol"
+// @Param   filter     query   string     false  "median, vwap or twap, default vwap"
+// @Param   window     query   string     false  "aggregation window, e.g. 1h, default 1h"
+// @Param   exchanges  query   string     false  "comma-separated exchange names, default all"
+// @Success 200 {object} aggregation.AggregatedQuotation "success"
+// @Failure 400 {object} restApi.APIError "bad request"
+// @Failure 404 {object} restApi.APIError "Symbol not found"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/quotation/:symbol/aggregate [get]
+func (env *Env) GetAggregatedQuotation(c *gin.Context) {
+	if env.Aggregator == nil {
+		restApi.SendError(c, http.StatusInternalServerError, errors.New("aggregation is not configured"))
+		return
+	}
+
+	symbol := c.Param("symbol")
+
+	filter, window, exchanges, err := parseAggregationParams(c)
+	if err != nil {
+		restApi.SendError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	q, err := env.Aggregator.Aggregate(symbol, filter, window, exchanges)
+	if err != nil {
+		if err == aggregation.ErrNoTrades {
+			restApi.SendError(c, http.StatusNotFound, err)
+		} else {
+			restApi.SendError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
 // GetSupply godoc
 // @Summary Get supply
 // @Description GetSupply
@@ -266,70 +493,267 @@ func (env *Env) GetAllSymbols(c *gin.Context) {
 }
 
 
-func (env *Env) GetAllTokenDetails(c *gin.Context){
-	var (
-		sto dia.Security_Token_Details
-		result gin.H
-	)
-	db, err := sql.Open("mysql", "root:@93MySQL@/sys")
+// GetAllTokenDetails godoc
+// @Summary Get security token details
+// @Description Get the full details of a registered security token
+// @Tags securitytoken
+// @Accept  json
+// @Produce  json
+// @Param   token_symbol     path    string     true        "Security token symbol"
+// @Success 200 {object} dia.Security_Token_Details "success"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/securityTokens/:token_symbol: [get]
+func (env *Env) GetAllTokenDetails(c *gin.Context) {
+	tokenSymbol := c.Param("token_symbol")
+
+	sto, err := env.SecurityTokens.Get(c.Request.Context(), tokenSymbol)
 	if err != nil {
-		log.Print(err.Error())
+		// If no results send null, matching the previous handler's behaviour.
+		c.JSON(http.StatusOK, gin.H{
+			"result": nil,
+			"count":  0,
+		})
+		return
 	}
-	defer db.Close()
-	// make sure connection is available
-	err = db.Ping()
+	c.JSON(http.StatusOK, gin.H{
+		"result": sto,
+		"count":  1,
+	})
+}
+
+// GetAllTokens godoc
+// @Summary Get security tokens
+// @Description Get the name and symbol of every registered security token
+// @Tags securitytoken
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dia.Security_Token_Symbols "success"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/securityTokens [get]
+func (env *Env) GetAllTokens(c *gin.Context) {
+	tokens, err := env.SecurityTokens.List(c.Request.Context())
 	if err != nil {
-		log.Print(err.Error())
+		restApi.SendError(c, http.StatusInternalServerError, err)
+		return
 	}
-	token_symbol := c.Param("token_symbol")
-	row := db.QueryRow("select token_name, token_status, token_symbol, industry, amount_raised, currency, issuance_price,min_invest, closing_date, target_investor_type, jurisdictions_avail, restricted_area, secondary_market, website, whitepaper, prospectus, smart_contract, github, blockchain, issuer_address, token_used, dividend, voting, equity_ownership, mme_class, interest, portfolio from SecurityTokens where token_symbol = ?;",token_symbol)
+	c.JSON(http.StatusOK, gin.H{
+		"result": tokens,
+		"count":  len(tokens),
+	})
+}
 
-	err = row.Scan(&sto.Token_Name, &sto.Token_Status, &sto.Token_Symbol, &sto.Industry, &sto.Amount_Raised, &sto.Currency, &sto.Issuance_Price, &sto.Min_Invest, &sto.Closing_Date, &sto.Target_Investor_Type, &sto.Jurisdictions_Avail, &sto.Restricted_Area, &sto.Secondary_Market, &sto.Website, &sto.Whitepaper, &sto.Prospectus, &sto.Smart_Contract, &sto.Github, &sto.Blockchain, &sto.Issuer_Address, &sto.Token_Used, &sto.Dividend, &sto.Voting, &sto.Equity_Ownership, &sto.MME_Class, &sto.Interest, &sto.Portfolio)
+// SearchTokens godoc
+// @Summary Search security tokens
+// @Description Search registered security tokens by industry, blockchain and/or status
+// @Tags securitytoken
+// @Accept  json
+// @Produce  json
+// @Param   industry     query    string     false        "Industry"
+// @Param   blockchain   query    string     false        "Blockchain"
+// @Param   status       query    string     false        "Token status"
+// @Success 200 {object} dia.Security_Token_Symbols "success"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/securityTokens/search [get]
+func (env *Env) SearchTokens(c *gin.Context) {
+	filter := securitytoken.Filter{
+		Industry:   c.Query("industry"),
+		Blockchain: c.Query("blockchain"),
+		Status:     c.Query("status"),
+	}
 
+	tokens, err := env.SecurityTokens.Search(c.Request.Context(), filter)
 	if err != nil {
-		// If no results send null
-		result = gin.H{
-			"result": nil,
-			"count":  0,
-		}
-	} else {
-		result = gin.H{
-			"result": sto,
-			"count":  1,
-		}
+		restApi.SendError(c, http.StatusInternalServerError, err)
+		return
 	}
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, gin.H{
+		"result": tokens,
+		"count":  len(tokens),
+	})
 }
 
-func (env *Env) GetAllTokens(c *gin.Context){
-	var (
-		sto  dia.Security_Token_Symbols
-		tokens []dia.Security_Token_Symbols
-	)
-	db, err := sql.Open("mysql", "root:@93MySQL@/sys")
-	if err != nil {
-		log.Print(err.Error())
+// PostSecurityToken godoc
+// @Summary Register a security token
+// @Description Register a new security token
+// @Tags securitytoken
+// @Accept  json
+// @Produce  json
+// @Param   token body dia.Security_Token_Details true "Security token details"
+// @Success 200 {object} dia.Security_Token_Details "success"
+// @Failure 400 {object} restApi.APIError "bad request"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/securityTokens [post]
+func (env *Env) PostSecurityToken(c *gin.Context) {
+	var t dia.Security_Token_Details
+	if err := json.NewDecoder(c.Request.Body).Decode(&t); err != nil {
+		restApi.SendError(c, http.StatusBadRequest, err)
+		return
 	}
-	defer db.Close()
-	// make sure connection is available
-	err = db.Ping()
-	if err != nil {
-		log.Print(err.Error())
+	if t.Token_Symbol == "" {
+		restApi.SendError(c, http.StatusBadRequest, errors.New("missing token_symbol"))
+		return
+	}
+
+	if err := env.SecurityTokens.Create(c.Request.Context(), &t); err != nil {
+		restApi.SendError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// PutSecurityToken godoc
+// @Summary Update a security token
+// @Description Update an already registered security token
+// @Tags securitytoken
+// @Accept  json
+// @Produce  json
+// @Param   token_symbol     path    string     true        "Security token symbol"
+// @Param   token body dia.Security_Token_Details true "Security token details"
+// @Success 200 {object} dia.Security_Token_Details "success"
+// @Failure 400 {object} restApi.APIError "bad request"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/securityTokens/:token_symbol: [put]
+func (env *Env) PutSecurityToken(c *gin.Context) {
+	var t dia.Security_Token_Details
+	if err := json.NewDecoder(c.Request.Body).Decode(&t); err != nil {
+		restApi.SendError(c, http.StatusBadRequest, err)
+		return
 	}
-	rows, err := db.Query("select token_name, token_symbol from SecurityTokens;")
+	t.Token_Symbol = c.Param("token_symbol")
+
+	if err := env.SecurityTokens.Update(c.Request.Context(), &t); err != nil {
+		restApi.SendError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// PostRotateSourceKey godoc
+// @Summary Rotate a source's signing key
+// @Description Register or replace the HMAC secret / Ed25519 public key an authenticated source signs requests with
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param   source     path    string     true        "Source identifier, e.g. the value sent as X-DIA-Key"
+// @Param   credential body restApi.SourceCredential true "New credential"
+// @Success 200 {object} restApi.SourceCredential "success"
+// @Failure 400 {object} restApi.APIError "bad request"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/admin/sources/:source/key [post]
+func (env *Env) PostRotateSourceKey(c *gin.Context) {
+	source := c.Param("source")
+
+	var cred restApi.SourceCredential
+	if err := json.NewDecoder(c.Request.Body).Decode(&cred); err != nil {
+		restApi.SendError(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(cred.Ed25519PubKey) != 0 && len(cred.Ed25519PubKey) != ed25519.PublicKeySize {
+		restApi.SendError(c, http.StatusBadRequest, fmt.Errorf("Ed25519PubKey must be %d bytes", ed25519.PublicKeySize))
+		return
+	}
+
+	if err := env.AuthKeys.Rotate(source, &cred); err != nil {
+		restApi.SendError(c, http.StatusInternalServerError, err)
+		return
+	}
+	cred.Source = source
+	c.JSON(http.StatusOK, cred)
+}
+
+// maxOracleDecimals bounds the decimals query parameter so scaleToFixedPoint
+// never has to scale by an astronomically large power of ten.
+const maxOracleDecimals = 18
+
+// scaleToFixedPoint converts a float64 price into the integer fixed-point
+// representation on-chain contracts expect, e.g. decimals=8 turns 1234.5
+// into 123450000000.
+func scaleToFixedPoint(price float64, decimals int) *big.Int {
+	scale := new(big.Float).SetFloat64(math.Pow10(decimals))
+	scaled := new(big.Float).Mul(big.NewFloat(price), scale)
+	result, _ := scaled.Int(nil)
+	return result
+}
+
+// PostPublishOracle godoc
+// @Summary Push the latest aggregated quotation on-chain
+// @Description Sign and submit the latest aggregated quotation for symbol to the configured Aggregator contract
+// @Tags oracle
+// @Accept  json
+// @Produce  json
+// @Param   symbol     path    string     true   "Some symbol"
+// @Param   filter     query   string     false  "median, vwap or twap, default vwap"
+// @Param   window     query   string     false  "aggregation window, e.g. 1h, default 1h"
+// @Param   exchanges  query   string     false  "comma-separated exchange names, default all"
+// @Param   decimals   query   int        false  "fixed-point decimals the on-chain price is scaled to, default 8"
+// @Success 200 {object} oracle.SubmitResult "success"
+// @Failure 400 {object} restApi.APIError "bad request"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/oracle/publish/:symbol [post]
+func (env *Env) PostPublishOracle(c *gin.Context) {
+	if env.Oracle == nil {
+		restApi.SendError(c, http.StatusInternalServerError, errors.New("oracle publishing is not configured"))
+		return
+	}
+	if env.Aggregator == nil {
+		restApi.SendError(c, http.StatusInternalServerError, errors.New("aggregation is not configured"))
+		return
+	}
+
+	symbol := c.Param("symbol")
+
+	filter, window, exchanges, err := parseAggregationParams(c)
 	if err != nil {
-		log.Print(err.Error())
+		restApi.SendError(c, http.StatusBadRequest, err)
+		return
 	}
-	for rows.Next() {
-		err = rows.Scan(&sto.Token_Name, &sto.Token_Symbol)
-		tokens = append(tokens, sto)
-		if err != nil {
-			log.Print(err.Error())
+
+	decimals, err := strconv.Atoi(c.DefaultQuery("decimals", "8"))
+	if err != nil || decimals < 0 || decimals > maxOracleDecimals {
+		decimals = 8
+	}
+
+	q, err := env.Aggregator.Aggregate(symbol, filter, window, exchanges)
+	if err != nil {
+		if err == aggregation.ErrNoTrades {
+			restApi.SendError(c, http.StatusNotFound, err)
+		} else {
+			restApi.SendError(c, http.StatusInternalServerError, err)
 		}
+		return
 	}
-	defer rows.Close()
-	c.JSON(http.StatusOK, gin.H{
-		"result": tokens,
-		"count":  len(tokens),
+
+	result, err := env.Oracle.Publish(c.Request.Context(), []oracle.Quotation{
+		{Symbol: symbol, Price: scaleToFixedPoint(q.Price, decimals)},
 	})
+	if err != nil {
+		restApi.SendError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetOracleStatus godoc
+// @Summary Get oracle submission status
+// @Description Get the on-chain confirmation status of a previously submitted oracle transaction
+// @Tags oracle
+// @Accept  json
+// @Produce  json
+// @Param   txhash     path    string     true   "Transaction hash"
+// @Success 200 {object} oracle.TxStatus "success"
+// @Failure 404 {object} restApi.APIError "Unknown transaction"
+// @Failure 500 {object} restApi.APIError "error"
+// @Router /v1/oracle/status/:txhash [get]
+func (env *Env) GetOracleStatus(c *gin.Context) {
+	if env.Oracle == nil {
+		restApi.SendError(c, http.StatusInternalServerError, errors.New("oracle publishing is not configured"))
+		return
+	}
+
+	status := env.Oracle.Status(c.Param("txhash"))
+	if status == nil {
+		restApi.SendError(c, http.StatusNotFound, errors.New("unknown transaction"))
+		return
+	}
+	c.JSON(http.StatusOK, status)
 }
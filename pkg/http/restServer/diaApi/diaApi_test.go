@@ -0,0 +1,29 @@
+package diaApi
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestScaleToFixedPoint(t *testing.T) {
+	cases := []struct {
+		price    float64
+		decimals int
+		want     string
+	}{
+		{price: 1234.5, decimals: 8, want: "123450000000"},
+		{price: 1, decimals: 0, want: "1"},
+		{price: 0.00000001, decimals: 8, want: "1"},
+	}
+
+	for _, c := range cases {
+		got := scaleToFixedPoint(c.price, c.decimals)
+		want, ok := new(big.Int).SetString(c.want, 10)
+		if !ok {
+			t.Fatalf("bad test case want %q", c.want)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("scaleToFixedPoint(%v, %d) = %s, want %s", c.price, c.decimals, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package diaApi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCoinNotFound is returned by a PriceProvider when the requested symbol
+// could not be resolved to a coin on the upstream source.
+var ErrCoinNotFound = errors.New("coin not found")
+
+// ErrTooManyRequests is returned by a PriceProvider when the upstream source
+// throttled the request.
+var ErrTooManyRequests = errors.New("too many requests")
+
+// MarketChartPoint is a single historical price sample returned by a
+// PriceProvider.
+type MarketChartPoint struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+}
+
+// PriceProvider is implemented by external quotation sources that GetQuotation
+// falls back on when DataStore has no data for a symbol.
+type PriceProvider interface {
+	// MarketChart returns historical price points for symbol over the given
+	// number of days, sampled at interval (e.g. "daily").
+	MarketChart(symbol string, days int, interval string) ([]MarketChartPoint, error)
+}
+
+// CoinGeckoConfig configures a CoinGeckoProvider. HeaderKey/APIKey are
+// optional and only sent when both are set, which covers both the free
+// (unauthenticated) and the Pro CoinGecko APIs.
+type CoinGeckoConfig struct {
+	BaseURL   string
+	HeaderKey string
+	APIKey    string
+}
+
+// coinListTTL bounds how long a fetched /coins/list is trusted before it is
+// refetched, so a symbol CoinGecko doesn't list (a bad or DIA-only symbol)
+// stops re-fetching the full 10k+ entry list on every request.
+const coinListTTL = 1 * time.Hour
+
+// CoinGeckoProvider is a PriceProvider backed by the CoinGecko REST API. It
+// keeps an in-memory symbol->coin id cache since CoinGecko has no endpoint
+// that accepts a ticker symbol directly.
+type CoinGeckoProvider struct {
+	config CoinGeckoConfig
+	client *http.Client
+
+	mu            sync.RWMutex
+	coinIDs       map[string]string
+	coinListFetch time.Time
+}
+
+// NewCoinGeckoProvider returns a CoinGeckoProvider. If config.BaseURL is
+// empty it defaults to the public CoinGecko API.
+func NewCoinGeckoProvider(config CoinGeckoConfig) *CoinGeckoProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.coingecko.com/api/v3"
+	}
+	return &CoinGeckoProvider{
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		coinIDs: make(map[string]string),
+	}
+}
+
+func (p *CoinGeckoProvider) setHeaders(req *http.Request) {
+	if p.config.HeaderKey != "" && p.config.APIKey != "" {
+		req.Header.Set(p.config.HeaderKey, p.config.APIKey)
+	}
+}
+
+// resolveCoinID maps a ticker symbol to a CoinGecko coin id, populating the
+// in-memory cache from /coins/list on first use. A symbol absent from a
+// cache fetched within coinListTTL is treated as unresolvable without
+// refetching, so repeated lookups for a bad or DIA-only symbol don't hammer
+// /coins/list on every call.
+func (p *CoinGeckoProvider) resolveCoinID(symbol string) (string, error) {
+	symbol = strings.ToLower(symbol)
+
+	p.mu.RLock()
+	id, ok := p.coinIDs[symbol]
+	fresh := time.Since(p.coinListFetch) < coinListTTL
+	p.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	if fresh {
+		return "", ErrCoinNotFound
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.config.BaseURL+"/coins/list", nil)
+	if err != nil {
+		return "", err
+	}
+	p.setHeaders(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", ErrTooManyRequests
+	}
+
+	var list []struct {
+		ID     string `json:"id"`
+		Symbol string `json:"symbol"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	for _, entry := range list {
+		if _, exists := p.coinIDs[entry.Symbol]; !exists {
+			p.coinIDs[entry.Symbol] = entry.ID
+		}
+	}
+	p.coinListFetch = time.Now()
+	id, ok = p.coinIDs[symbol]
+	p.mu.Unlock()
+
+	if !ok {
+		return "", ErrCoinNotFound
+	}
+	return id, nil
+}
+
+// MarketChart implements PriceProvider by calling CoinGecko's
+// /coins/{id}/market_chart endpoint.
+func (p *CoinGeckoProvider) MarketChart(symbol string, days int, interval string) ([]MarketChartPoint, error) {
+	coinID, err := p.resolveCoinID(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/coins/%s/market_chart?vs_currency=usd&days=%d&interval=%s", p.config.BaseURL, coinID, days, interval)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.setHeaders(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrCoinNotFound
+	case http.StatusTooManyRequests:
+		return nil, ErrTooManyRequests
+	}
+
+	var payload struct {
+		Prices [][2]float64 `json:"prices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	points := make([]MarketChartPoint, 0, len(payload.Prices))
+	for _, sample := range payload.Prices {
+		points = append(points, MarketChartPoint{
+			Time:  time.Unix(0, int64(sample[0])*int64(time.Millisecond)),
+			Price: sample[1],
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,82 @@
+package diaApi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestCoinGeckoProvider(t *testing.T, hits *int) (*CoinGeckoProvider, func()) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits++
+		json.NewEncoder(w).Encode([]struct {
+			ID     string `json:"id"`
+			Symbol string `json:"symbol"`
+		}{
+			{ID: "bitcoin", Symbol: "btc"},
+		})
+	}))
+	provider := NewCoinGeckoProvider(CoinGeckoConfig{BaseURL: server.URL})
+	return provider, server.Close
+}
+
+func TestResolveCoinIDCachesHits(t *testing.T) {
+	var hits int
+	provider, closeServer := newTestCoinGeckoProvider(t, &hits)
+	defer closeServer()
+
+	id, err := provider.resolveCoinID("btc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "bitcoin" {
+		t.Fatalf("expected bitcoin, got %s", id)
+	}
+
+	if _, err := provider.resolveCoinID("btc"); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 1 {
+		t.Fatalf("expected a cache hit to skip refetching /coins/list, got %d fetches", hits)
+	}
+}
+
+func TestResolveCoinIDCachesMissesWithinTTL(t *testing.T) {
+	var hits int
+	provider, closeServer := newTestCoinGeckoProvider(t, &hits)
+	defer closeServer()
+
+	if _, err := provider.resolveCoinID("nosuchcoin"); err != ErrCoinNotFound {
+		t.Fatalf("expected ErrCoinNotFound, got %v", err)
+	}
+	if _, err := provider.resolveCoinID("nosuchcoin"); err != ErrCoinNotFound {
+		t.Fatalf("expected ErrCoinNotFound, got %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("expected a repeated miss within the TTL to skip refetching /coins/list, got %d fetches", hits)
+	}
+}
+
+func TestResolveCoinIDRefetchesAfterTTL(t *testing.T) {
+	var hits int
+	provider, closeServer := newTestCoinGeckoProvider(t, &hits)
+	defer closeServer()
+
+	if _, err := provider.resolveCoinID("nosuchcoin"); err != ErrCoinNotFound {
+		t.Fatalf("expected ErrCoinNotFound, got %v", err)
+	}
+
+	provider.mu.Lock()
+	provider.coinListFetch = time.Now().Add(-coinListTTL - time.Second)
+	provider.mu.Unlock()
+
+	if _, err := provider.resolveCoinID("nosuchcoin"); err != ErrCoinNotFound {
+		t.Fatalf("expected ErrCoinNotFound, got %v", err)
+	}
+	if hits != 2 {
+		t.Fatalf("expected the cache to refetch /coins/list once the TTL elapsed, got %d fetches", hits)
+	}
+}
@@ -0,0 +1,257 @@
+package diaApi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	wsWriteWait    = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsClientBuffer = 64
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// subscribeMessage is the control frame clients send to (un)subscribe to
+// channels, e.g. {"op":"subscribe","channels":["quotation:BTC","trades:ETH"]}.
+type subscribeMessage struct {
+	Op       string   `json:"op"`
+	Channels []string `json:"channels"`
+}
+
+// streamHub fans Redis pub/sub messages out to local websocket clients. A
+// single hub is shared by every /v1/ws connection on this replica, and Redis
+// pub/sub (rather than an in-process broadcast) is what lets multiple API
+// replicas stay in sync. Messages reach it through Redis: in this package,
+// Publish is called only by the quotation fallback-cache write in
+// getQuotationFromProvider, and any other producer that publishes to the
+// same "quotation:<symbol>"/"trades:<symbol>" Redis channels reaches the
+// same clients.
+type streamHub struct {
+	redis  *redis.Client
+	pubsub *redis.PubSub
+
+	mu         sync.Mutex
+	clients    map[*wsClient]bool
+	subscribed map[string]bool
+
+	register   chan *wsClient
+	unregister chan *wsClient
+}
+
+func newStreamHub(client *redis.Client) *streamHub {
+	return &streamHub{
+		redis:      client,
+		pubsub:     client.Subscribe(),
+		clients:    make(map[*wsClient]bool),
+		subscribed: make(map[string]bool),
+		register:   make(chan *wsClient),
+		unregister: make(chan *wsClient),
+	}
+}
+
+// run processes hub events until the hub's pub/sub connection is closed. It
+// is meant to be started once, in its own goroutine, for the lifetime of Env.
+func (h *streamHub) run() {
+	messages := h.pubsub.Channel()
+	for {
+		select {
+		case cl := <-h.register:
+			h.mu.Lock()
+			h.clients[cl] = true
+			h.mu.Unlock()
+		case cl := <-h.unregister:
+			h.mu.Lock()
+			if _, ok := h.clients[cl]; ok {
+				delete(h.clients, cl)
+				close(cl.send)
+			}
+			h.mu.Unlock()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			h.broadcast(msg.Channel, []byte(msg.Payload))
+		}
+	}
+}
+
+func (h *streamHub) broadcast(topic string, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for cl := range h.clients {
+		cl.tryDeliver(topic, payload)
+	}
+}
+
+// Publish marshals payload to JSON and publishes it to topic on Redis, so
+// every API replica's hub (and any of its subscribed websocket clients)
+// fans it out via run's message loop.
+func (h *streamHub) Publish(topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return h.redis.Publish(topic, data).Err()
+}
+
+// ensureSubscribed adds topic to the shared Redis subscription the first
+// time a local client asks for it.
+func (h *streamHub) ensureSubscribed(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribed[topic] {
+		return
+	}
+	if err := h.pubsub.Subscribe(topic); err != nil {
+		log.Errorln("ws: subscribe", topic, err)
+		return
+	}
+	h.subscribed[topic] = true
+}
+
+// wsClient bridges one websocket connection to the hub. Outgoing messages
+// are queued on a bounded ring buffer (send); a client that cannot drain it
+// fast enough is treated as a slow consumer and disconnected rather than
+// blocking every other subscriber.
+type wsClient struct {
+	conn *websocket.Conn
+	send chan []byte
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func newWSClient(conn *websocket.Conn) *wsClient {
+	return &wsClient{
+		conn:   conn,
+		send:   make(chan []byte, wsClientBuffer),
+		topics: make(map[string]bool),
+	}
+}
+
+func (cl *wsClient) isSubscribed(topic string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.topics[topic]
+}
+
+func (cl *wsClient) setSubscribed(topic string, on bool) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if on {
+		cl.topics[topic] = true
+	} else {
+		delete(cl.topics, topic)
+	}
+}
+
+func (cl *wsClient) tryDeliver(topic string, payload []byte) {
+	if !cl.isSubscribed(topic) {
+		return
+	}
+	select {
+	case cl.send <- payload:
+	default:
+		log.Warnln("ws: slow consumer, disconnecting")
+		cl.conn.Close()
+	}
+}
+
+// writePump relays queued messages to the connection and sends a heartbeat
+// ping every wsPingInterval. It must run in its own goroutine.
+func (cl *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer func() {
+		ticker.Stop()
+		cl.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-cl.send:
+			cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				cl.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := cl.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := cl.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump handles subscribe/unsubscribe control frames until the
+// connection is closed, then unregisters the client from the hub.
+func (cl *wsClient) readPump(hub *streamHub) {
+	defer func() {
+		hub.unregister <- cl
+		cl.conn.Close()
+	}()
+
+	for {
+		_, data, err := cl.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var sub subscribeMessage
+		if err := json.Unmarshal(data, &sub); err != nil {
+			continue
+		}
+		switch sub.Op {
+		case "subscribe":
+			for _, topic := range sub.Channels {
+				cl.setSubscribed(topic, true)
+				hub.ensureSubscribed(topic)
+			}
+		case "unsubscribe":
+			for _, topic := range sub.Channels {
+				cl.setSubscribed(topic, false)
+			}
+		}
+	}
+}
+
+// GetWS godoc
+// @Summary Stream quotations and trades
+// @Description Upgrade to a websocket and subscribe to per-symbol quotation, chart-point and trade updates
+// @Tags dia
+// @Router /v1/ws [get]
+func (env *Env) GetWS(c *gin.Context) {
+	if env.stream == nil {
+		restApi.SendError(c, http.StatusInternalServerError, errNoStreamHub)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Errorln("ws: upgrade", err)
+		return
+	}
+
+	cl := newWSClient(conn)
+	env.stream.register <- cl
+
+	go cl.writePump()
+	cl.readPump(env.stream)
+}
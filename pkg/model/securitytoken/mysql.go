@@ -0,0 +1,13 @@
+package securitytoken
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func newMySQLRepository(dsn string) (Repository, error) {
+	db, err := openPooled("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlRepository{db: db, placeholder: questionPlaceholder}, nil
+}
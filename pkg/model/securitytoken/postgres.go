@@ -0,0 +1,13 @@
+package securitytoken
+
+import (
+	_ "github.com/lib/pq"
+)
+
+func newPostgresRepository(dsn string) (Repository, error) {
+	db, err := openPooled("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlRepository{db: db, placeholder: dollarPlaceholder}, nil
+}
@@ -0,0 +1,62 @@
+// Package securitytoken provides a DB-agnostic repository for the
+// SecurityTokens table, replacing the per-request sql.Open calls that used
+// to live in the REST handlers.
+package securitytoken
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/diadata-org/diadata/pkg/dia"
+)
+
+// Filter narrows down a Search call; zero-value fields are ignored.
+type Filter struct {
+	Industry   string
+	Blockchain string
+	Status     string
+}
+
+// Repository provides CRUD access to the SecurityTokens table.
+type Repository interface {
+	List(ctx context.Context) ([]dia.Security_Token_Symbols, error)
+	Search(ctx context.Context, filter Filter) ([]dia.Security_Token_Symbols, error)
+	Get(ctx context.Context, symbol string) (*dia.Security_Token_Details, error)
+	Create(ctx context.Context, t *dia.Security_Token_Details) error
+	Update(ctx context.Context, t *dia.Security_Token_Details) error
+	Close() error
+}
+
+// NewRepository opens a Repository for dsn, picking the SQL dialect from its
+// scheme. "mysql://", "postgres://"/"postgresql://" and "sqlite://" are
+// supported.
+func NewRepository(dsn string) (Repository, error) {
+	switch {
+	case strings.HasPrefix(dsn, "mysql://"):
+		return newMySQLRepository(strings.TrimPrefix(dsn, "mysql://"))
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return newPostgresRepository(dsn)
+	case strings.HasPrefix(dsn, "sqlite://"):
+		return newSQLiteRepository(strings.TrimPrefix(dsn, "sqlite://"))
+	default:
+		return nil, fmt.Errorf("securitytoken: unsupported DSN %q", dsn)
+	}
+}
+
+// openPooled opens a connection pool for driver/dsn and verifies it is
+// reachable before handing it back.
+func openPooled(driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,193 @@
+package securitytoken
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/diadata-org/diadata/pkg/dia"
+)
+
+// securityTokenColumns mirrors the fields scanned into dia.Security_Token_Details,
+// in column order.
+var securityTokenColumns = []string{
+	"token_name", "token_status", "token_symbol", "industry", "amount_raised", "currency",
+	"issuance_price", "min_invest", "closing_date", "target_investor_type", "jurisdictions_avail",
+	"restricted_area", "secondary_market", "website", "whitepaper", "prospectus", "smart_contract",
+	"github", "blockchain", "issuer_address", "token_used", "dividend", "voting",
+	"equity_ownership", "mme_class", "interest", "portfolio",
+}
+
+// placeholderFunc renders the i-th (1-based) bind parameter for a dialect,
+// e.g. "?" for MySQL/SQLite or "$1" for Postgres.
+type placeholderFunc func(i int) string
+
+func questionPlaceholder(int) string { return "?" }
+
+func dollarPlaceholder(i int) string { return fmt.Sprintf("$%d", i) }
+
+// sqlRepository implements Repository against any database/sql driver, given
+// its placeholder style. The per-dialect constructors in mysql.go,
+// postgres.go and sqlite.go only differ in driver name and placeholder.
+type sqlRepository struct {
+	db          *sql.DB
+	placeholder placeholderFunc
+
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
+}
+
+// prepare returns a cached *sql.Stmt for query, preparing it on first use.
+// List/Get/Create/Update always query with the same fixed SQL, and Search
+// has only as many distinct shapes as there are filter combinations, so
+// keying the cache by query text reuses the prepared statement across calls
+// instead of re-parsing and re-planning it every time.
+func (r *sqlRepository) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	r.stmtMu.Lock()
+	defer r.stmtMu.Unlock()
+
+	if stmt, ok := r.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if r.stmts == nil {
+		r.stmts = make(map[string]*sql.Stmt)
+	}
+	r.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (r *sqlRepository) List(ctx context.Context) ([]dia.Security_Token_Symbols, error) {
+	return r.listQuery(ctx, "select token_name, token_symbol from SecurityTokens", nil)
+}
+
+func (r *sqlRepository) Search(ctx context.Context, filter Filter) ([]dia.Security_Token_Symbols, error) {
+	query, args := buildSearchQuery(filter, r.placeholder)
+	return r.listQuery(ctx, query, args)
+}
+
+// buildSearchQuery renders Search's dynamic WHERE clause, appending each
+// non-empty filter field as a bound "column = placeholder" clause. Split out
+// from Search so the clause building can be unit tested without a live
+// database connection.
+func buildSearchQuery(filter Filter, placeholder placeholderFunc) (string, []interface{}) {
+	query := "select token_name, token_symbol from SecurityTokens where 1=1"
+	var args []interface{}
+	addClause := func(column, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		query += fmt.Sprintf(" and %s = %s", column, placeholder(len(args)))
+	}
+	addClause("industry", filter.Industry)
+	addClause("blockchain", filter.Blockchain)
+	addClause("token_status", filter.Status)
+	return query, args
+}
+
+func (r *sqlRepository) listQuery(ctx context.Context, query string, args []interface{}) ([]dia.Security_Token_Symbols, error) {
+	stmt, err := r.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []dia.Security_Token_Symbols
+	for rows.Next() {
+		var t dia.Security_Token_Symbols
+		if err := rows.Scan(&t.Token_Name, &t.Token_Symbol); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *sqlRepository) Get(ctx context.Context, symbol string) (*dia.Security_Token_Details, error) {
+	query := fmt.Sprintf(
+		"select %s from SecurityTokens where token_symbol = %s",
+		strings.Join(securityTokenColumns, ", "), r.placeholder(1),
+	)
+	stmt, err := r.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	row := stmt.QueryRowContext(ctx, symbol)
+
+	var t dia.Security_Token_Details
+	err = row.Scan(
+		&t.Token_Name, &t.Token_Status, &t.Token_Symbol, &t.Industry, &t.Amount_Raised, &t.Currency,
+		&t.Issuance_Price, &t.Min_Invest, &t.Closing_Date, &t.Target_Investor_Type, &t.Jurisdictions_Avail,
+		&t.Restricted_Area, &t.Secondary_Market, &t.Website, &t.Whitepaper, &t.Prospectus, &t.Smart_Contract,
+		&t.Github, &t.Blockchain, &t.Issuer_Address, &t.Token_Used, &t.Dividend, &t.Voting,
+		&t.Equity_Ownership, &t.MME_Class, &t.Interest, &t.Portfolio,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func securityTokenArgs(t *dia.Security_Token_Details) []interface{} {
+	return []interface{}{
+		t.Token_Name, t.Token_Status, t.Token_Symbol, t.Industry, t.Amount_Raised, t.Currency,
+		t.Issuance_Price, t.Min_Invest, t.Closing_Date, t.Target_Investor_Type, t.Jurisdictions_Avail,
+		t.Restricted_Area, t.Secondary_Market, t.Website, t.Whitepaper, t.Prospectus, t.Smart_Contract,
+		t.Github, t.Blockchain, t.Issuer_Address, t.Token_Used, t.Dividend, t.Voting,
+		t.Equity_Ownership, t.MME_Class, t.Interest, t.Portfolio,
+	}
+}
+
+func (r *sqlRepository) Create(ctx context.Context, t *dia.Security_Token_Details) error {
+	placeholders := make([]string, len(securityTokenColumns))
+	for i := range placeholders {
+		placeholders[i] = r.placeholder(i + 1)
+	}
+	query := fmt.Sprintf(
+		"insert into SecurityTokens (%s) values (%s)",
+		strings.Join(securityTokenColumns, ", "), strings.Join(placeholders, ", "),
+	)
+	stmt, err := r.prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, securityTokenArgs(t)...)
+	return err
+}
+
+func (r *sqlRepository) Update(ctx context.Context, t *dia.Security_Token_Details) error {
+	assignments := make([]string, len(securityTokenColumns))
+	for i, column := range securityTokenColumns {
+		assignments[i] = fmt.Sprintf("%s = %s", column, r.placeholder(i+1))
+	}
+	query := fmt.Sprintf(
+		"update SecurityTokens set %s where token_symbol = %s",
+		strings.Join(assignments, ", "), r.placeholder(len(securityTokenColumns)+1),
+	)
+	stmt, err := r.prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+	args := append(securityTokenArgs(t), t.Token_Symbol)
+	_, err = stmt.ExecContext(ctx, args...)
+	return err
+}
+
+func (r *sqlRepository) Close() error {
+	r.stmtMu.Lock()
+	for _, stmt := range r.stmts {
+		stmt.Close()
+	}
+	r.stmtMu.Unlock()
+	return r.db.Close()
+}
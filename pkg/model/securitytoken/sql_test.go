@@ -0,0 +1,57 @@
+package securitytoken
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSearchQuery(t *testing.T) {
+	cases := []struct {
+		name      string
+		filter    Filter
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "no filters",
+			filter:    Filter{},
+			wantQuery: "select token_name, token_symbol from SecurityTokens where 1=1",
+			wantArgs:  nil,
+		},
+		{
+			name:      "single filter is bound, not inlined",
+			filter:    Filter{Industry: "Real Estate"},
+			wantQuery: "select token_name, token_symbol from SecurityTokens where 1=1 and industry = ?",
+			wantArgs:  []interface{}{"Real Estate"},
+		},
+		{
+			name:      "every filter binds its own placeholder in order",
+			filter:    Filter{Industry: "Real Estate", Blockchain: "Ethereum", Status: "active"},
+			wantQuery: "select token_name, token_symbol from SecurityTokens where 1=1 and industry = ? and blockchain = ? and token_status = ?",
+			wantArgs:  []interface{}{"Real Estate", "Ethereum", "active"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			query, args := buildSearchQuery(c.filter, questionPlaceholder)
+			if query != c.wantQuery {
+				t.Errorf("query = %q, want %q", query, c.wantQuery)
+			}
+			if !reflect.DeepEqual(args, c.wantArgs) {
+				t.Errorf("args = %v, want %v", args, c.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildSearchQueryUsesDialectPlaceholder(t *testing.T) {
+	query, args := buildSearchQuery(Filter{Industry: "Real Estate", Status: "active"}, dollarPlaceholder)
+	want := "select token_name, token_symbol from SecurityTokens where 1=1 and industry = $1 and token_status = $2"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 2 {
+		t.Errorf("expected 2 args, got %d", len(args))
+	}
+}
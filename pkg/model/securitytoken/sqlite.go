@@ -0,0 +1,13 @@
+package securitytoken
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newSQLiteRepository(path string) (Repository, error) {
+	db, err := openPooled("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlRepository{db: db, placeholder: questionPlaceholder}, nil
+}
@@ -0,0 +1,14 @@
+package sourcekey
+
+import (
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func newMySQLStore(dsn string) (restApi.SourceKeyStore, error) {
+	db, err := openPooled("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStore{db: db, placeholder: questionPlaceholder}, nil
+}
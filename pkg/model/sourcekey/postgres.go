@@ -0,0 +1,14 @@
+package sourcekey
+
+import (
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+	_ "github.com/lib/pq"
+)
+
+func newPostgresStore(dsn string) (restApi.SourceKeyStore, error) {
+	db, err := openPooled("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStore{db: db, placeholder: dollarPlaceholder}, nil
+}
@@ -0,0 +1,68 @@
+package sourcekey
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+)
+
+// placeholderFunc renders the i-th (1-based) bind parameter for a dialect,
+// e.g. "?" for MySQL/SQLite or "$1" for Postgres.
+type placeholderFunc func(i int) string
+
+func questionPlaceholder(int) string { return "?" }
+
+func dollarPlaceholder(i int) string { return fmt.Sprintf("$%d", i) }
+
+// sqlStore implements restApi.SourceKeyStore against any database/sql
+// driver, given its placeholder style. The per-dialect constructors in
+// mysql.go, postgres.go and sqlite.go only differ in driver name and
+// placeholder.
+type sqlStore struct {
+	db          *sql.DB
+	placeholder placeholderFunc
+}
+
+func (s *sqlStore) Get(source string) (*restApi.SourceCredential, error) {
+	query := fmt.Sprintf(
+		"select source, hmac_secret, ed25519_pub_key from SourceCredentials where source = %s",
+		s.placeholder(1),
+	)
+	row := s.db.QueryRow(query, source)
+
+	var cred restApi.SourceCredential
+	var hmacSecret sql.NullString
+	if err := row.Scan(&cred.Source, &hmacSecret, &cred.Ed25519PubKey); err != nil {
+		return nil, err
+	}
+	cred.HMACSecret = hmacSecret.String
+	return &cred, nil
+}
+
+// Rotate replaces source's stored credential with cred in a single
+// transaction, so a concurrent Get never observes a source with no
+// credential at all.
+func (s *sqlStore) Rotate(source string, cred *restApi.SourceCredential) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	deleteQuery := fmt.Sprintf("delete from SourceCredentials where source = %s", s.placeholder(1))
+	if _, err := tx.Exec(deleteQuery, source); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	insertQuery := fmt.Sprintf(
+		"insert into SourceCredentials (source, hmac_secret, ed25519_pub_key) values (%s, %s, %s)",
+		s.placeholder(1), s.placeholder(2), s.placeholder(3),
+	)
+	if _, err := tx.Exec(insertQuery, source, cred.HMACSecret, cred.Ed25519PubKey); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
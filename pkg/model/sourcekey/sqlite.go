@@ -0,0 +1,14 @@
+package sourcekey
+
+import (
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newSQLiteStore(path string) (restApi.SourceKeyStore, error) {
+	db, err := openPooled("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStore{db: db, placeholder: questionPlaceholder}, nil
+}
@@ -0,0 +1,43 @@
+// Package sourcekey provides a DB-agnostic restApi.SourceKeyStore backing
+// AuthMiddleware's per-source signing credentials.
+package sourcekey
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/diadata-org/diadata/pkg/http/restApi"
+)
+
+// NewStore opens a restApi.SourceKeyStore for dsn, picking the SQL dialect
+// from its scheme. "mysql://", "postgres://"/"postgresql://" and
+// "sqlite://" are supported.
+func NewStore(dsn string) (restApi.SourceKeyStore, error) {
+	switch {
+	case strings.HasPrefix(dsn, "mysql://"):
+		return newMySQLStore(strings.TrimPrefix(dsn, "mysql://"))
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return newPostgresStore(dsn)
+	case strings.HasPrefix(dsn, "sqlite://"):
+		return newSQLiteStore(strings.TrimPrefix(dsn, "sqlite://"))
+	default:
+		return nil, fmt.Errorf("sourcekey: unsupported DSN %q", dsn)
+	}
+}
+
+// openPooled opens a connection pool for driver/dsn and verifies it is
+// reachable before handing it back.
+func openPooled(driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,20 @@
+package oracle
+
+import "time"
+
+// Config configures a Publisher, following the EthereumConfig pattern common
+// to oracle-emitter style services: an RPC endpoint, the Aggregator contract
+// to call, a gas cap, and the node's signing key.
+type Config struct {
+	RPCURL          string
+	ContractAddress string
+	// PrivateKey is hex-encoded, without a "0x" prefix.
+	PrivateKey string
+	GasLimit   uint64
+	// Epoch is the granularity submissions are rounded up to, e.g. 5 minutes.
+	Epoch time.Duration
+	// DryRun, when true, makes Publish return ABI-encoded calldata instead
+	// of broadcasting a transaction. No RPC connection is required in this
+	// mode.
+	DryRun bool
+}
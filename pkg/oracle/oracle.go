@@ -0,0 +1,268 @@
+// Package oracle signs aggregated quotations and pushes them to an on-chain
+// Aggregator contract, turning DIA's REST API into a push oracle usable by
+// DeFi contracts.
+package oracle
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// aggregatorABI is the minimal ABI for the Aggregator contract's write path:
+// submit(bytes32[] keys, uint256[] values, uint64 epoch).
+const aggregatorABI = `[{"inputs":[{"internalType":"bytes32[]","name":"keys","type":"bytes32[]"},{"internalType":"uint256[]","name":"values","type":"uint256[]"},{"internalType":"uint64","name":"epoch","type":"uint64"}],"name":"submit","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+
+// Quotation is one symbol's price to be batched into a single submit call.
+// Price is expected to already be scaled to the contract's fixed-point
+// precision.
+type Quotation struct {
+	Symbol string
+	Price  *big.Int
+}
+
+// SubmitResult is returned by Publish. TxHash is empty in dry-run mode, in
+// which case CallData holds the ABI-encoded calldata instead.
+type SubmitResult struct {
+	TxHash   string `json:"txHash,omitempty"`
+	CallData string `json:"callData,omitempty"`
+	Epoch    uint64 `json:"epoch"`
+	DryRun   bool   `json:"dryRun"`
+}
+
+// TxStatus is the latest known state of a submitted transaction.
+type TxStatus struct {
+	TxHash    string `json:"txHash"`
+	Confirmed bool   `json:"confirmed"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Publisher batches aggregated quotations into submit() calls against a
+// configured Aggregator contract and asynchronously tracks their on-chain
+// confirmation.
+type Publisher struct {
+	config  Config
+	client  *ethclient.Client
+	abi     abi.ABI
+	address common.Address
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+
+	// sendMu serializes nonce allocation and broadcast so two concurrent
+	// Publish calls can't fetch the same pending nonce.
+	sendMu sync.Mutex
+
+	mu       sync.RWMutex
+	statuses map[string]*trackedStatus
+}
+
+// trackedStatus pairs a TxStatus with the time it was created, so statusTTL
+// lets old entries be pruned instead of accumulating forever.
+type trackedStatus struct {
+	status    TxStatus
+	createdAt time.Time
+}
+
+// statusTTL bounds how long a Publisher remembers a transaction's status.
+const statusTTL = 24 * time.Hour
+
+// NewPublisher parses config and, unless config.DryRun is set, dials
+// config.RPCURL.
+func NewPublisher(config Config) (*Publisher, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(aggregatorABI))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := crypto.HexToECDSA(config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse oracle private key: %w", err)
+	}
+
+	p := &Publisher{
+		config:   config,
+		abi:      parsedABI,
+		address:  common.HexToAddress(config.ContractAddress),
+		key:      key,
+		statuses: make(map[string]*trackedStatus),
+	}
+
+	if config.DryRun {
+		return p, nil
+	}
+
+	client, err := ethclient.Dial(config.RPCURL)
+	if err != nil {
+		return nil, err
+	}
+	p.client = client
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	p.chainID = chainID
+
+	return p, nil
+}
+
+// RoundEpoch rounds t up to the next multiple of config.Epoch, generalizing
+// the diaApi package's roundUpTime helper to an arbitrary granularity.
+func (p *Publisher) RoundEpoch(t time.Time) time.Time {
+	if p.config.Epoch <= 0 {
+		return t
+	}
+	rounded := t.Round(p.config.Epoch)
+	if rounded.Before(t) {
+		rounded = rounded.Add(p.config.Epoch)
+	}
+	return rounded
+}
+
+// Publish batches quotations into a single submit() call at the current
+// epoch. In dry-run mode it returns the ABI-encoded calldata without
+// broadcasting; otherwise it signs and sends the transaction and starts
+// tracking its confirmation in the background.
+func (p *Publisher) Publish(ctx context.Context, quotations []Quotation) (*SubmitResult, error) {
+	if len(quotations) == 0 {
+		return nil, errors.New("no quotations to publish")
+	}
+
+	epoch := uint64(p.RoundEpoch(time.Now()).Unix())
+
+	keys := make([][32]byte, len(quotations))
+	values := make([]*big.Int, len(quotations))
+	for i, q := range quotations {
+		keys[i] = symbolToBytes32(q.Symbol)
+		values[i] = q.Price
+	}
+
+	data, err := p.abi.Pack("submit", keys, values, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config.DryRun {
+		return &SubmitResult{
+			CallData: "0x" + hex.EncodeToString(data),
+			Epoch:    epoch,
+			DryRun:   true,
+		}, nil
+	}
+
+	tx, err := p.send(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := tx.Hash().Hex()
+	p.mu.Lock()
+	p.statuses[txHash] = &trackedStatus{status: TxStatus{TxHash: txHash}, createdAt: time.Now()}
+	p.pruneStatusesLocked()
+	p.mu.Unlock()
+
+	go p.awaitReceipt(txHash, tx)
+
+	return &SubmitResult{TxHash: txHash, Epoch: epoch}, nil
+}
+
+// send allocates the next nonce and broadcasts the transaction. sendMu
+// serializes this so two concurrent Publish calls can't both observe the
+// same pending nonce and clobber each other's submission.
+func (p *Publisher) send(ctx context.Context, data []byte) (*types.Transaction, error) {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	from := crypto.PubkeyToAddress(p.key.PublicKey)
+
+	nonce, err := p.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := p.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := types.NewTransaction(nonce, p.address, big.NewInt(0), p.config.GasLimit, gasPrice, data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(p.chainID), p.key)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.client.SendTransaction(ctx, signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// awaitReceipt polls for a transaction's receipt and records the outcome so
+// Status can report it without blocking the caller of Publish.
+func (p *Publisher) awaitReceipt(txHash string, tx *types.Transaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	receipt, err := bind.WaitMined(ctx, p.client, tx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tracked := p.statuses[txHash]
+	if tracked == nil {
+		tracked = &trackedStatus{status: TxStatus{TxHash: txHash}, createdAt: time.Now()}
+		p.statuses[txHash] = tracked
+	}
+	if err != nil {
+		log.Errorln("oracle: wait mined", txHash, err)
+		tracked.status.Error = err.Error()
+		return
+	}
+	tracked.status.Confirmed = true
+	tracked.status.Success = receipt.Status == types.ReceiptStatusSuccessful
+}
+
+// pruneStatusesLocked drops tracked statuses older than statusTTL. Callers
+// must hold p.mu.
+func (p *Publisher) pruneStatusesLocked() {
+	cutoff := time.Now().Add(-statusTTL)
+	for txHash, tracked := range p.statuses {
+		if tracked.createdAt.Before(cutoff) {
+			delete(p.statuses, txHash)
+		}
+	}
+}
+
+// Status returns the last known status for txHash, or nil if it is unknown
+// to this Publisher (e.g. after a restart, a dry-run call, or because it has
+// aged out past statusTTL).
+func (p *Publisher) Status(txHash string) *TxStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	tracked, ok := p.statuses[txHash]
+	if !ok {
+		return nil
+	}
+	status := tracked.status
+	return &status
+}
+
+// symbolToBytes32 left-aligns symbol into a bytes32 key, matching the
+// Aggregator contract's key encoding.
+func symbolToBytes32(symbol string) [32]byte {
+	var key [32]byte
+	copy(key[:], []byte(symbol))
+	return key
+}
@@ -0,0 +1,38 @@
+package oracle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoundEpoch(t *testing.T) {
+	publisher := &Publisher{config: Config{Epoch: 5 * time.Minute}}
+
+	base := time.Date(2026, 7, 27, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		in   time.Time
+		want time.Time
+	}{
+		{name: "already on the epoch boundary", in: base, want: base},
+		{name: "just past the boundary rounds up", in: base.Add(time.Second), want: base.Add(5 * time.Minute)},
+		{name: "just before the next boundary rounds up", in: base.Add(5*time.Minute - time.Second), want: base.Add(5 * time.Minute)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := publisher.RoundEpoch(c.in)
+			if !got.Equal(c.want) {
+				t.Errorf("RoundEpoch(%v) = %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRoundEpochNoopWhenUnconfigured(t *testing.T) {
+	publisher := &Publisher{config: Config{Epoch: 0}}
+	now := time.Date(2026, 7, 27, 10, 3, 17, 0, time.UTC)
+	if got := publisher.RoundEpoch(now); !got.Equal(now) {
+		t.Errorf("RoundEpoch with no configured epoch should be a no-op, got %v want %v", got, now)
+	}
+}